@@ -1,15 +1,22 @@
 package vsphere
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/customattribute"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/virtualdevice"
+	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -56,9 +63,92 @@ func dataSourceVSphereVirtualMachine() *schema.Resource {
 							Type:     schema.TypeBool,
 							Computed: true,
 						},
+						"controller_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of controller the disk is attached to: scsi, ide, sata, or nvme.",
+						},
+						"controller_bus_number": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The bus number of the controller the disk is attached to.",
+						},
+						"unit_number": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The unit number of the disk on its controller.",
+						},
+						"label": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The label of the disk, as seen in the vSphere UI.",
+						},
+						"datastore_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object ID of the datastore the disk lives on.",
+						},
+						"disk_mode": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The mode of this disk, such as persistent or independent_persistent.",
+						},
+						"write_through": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether or not writes are immediately committed to the disk, bypassing the datastore's write cache.",
+						},
+						"sharing": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The sharing mode of this disk, such as sharingNone or sharingMultiWriter.",
+						},
+						"io_limit": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The upper I/O limit set on this disk, in IOPS. -1 means unlimited.",
+						},
+						"io_reservation": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The I/O reservation set on this disk, in IOPS.",
+						},
 					},
 				},
 			},
+			"disk_count": {
+				Type:        schema.TypeInt,
+				Description: "The number of disks found on the virtual machine, across all scanned controllers.",
+				Computed:    true,
+			},
+			"disk_controller_summary": {
+				Type:        schema.TypeList,
+				Description: "A per-controller summary of the disks found on the virtual machine, one entry per controller.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"controller_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"bus_number": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"disk_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"disk_scan_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "scsi_only",
+				Description:  "How to scan for disk attributes: scsi_only looks only at SCSI controllers (the historical behavior), while all_controllers also scans IDE, SATA, and NVMe controllers.",
+				ValidateFunc: validation.StringInSlice([]string{"scsi_only", "all_controllers"}, false),
+			},
 			"enable_disk_uuid": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -69,6 +159,23 @@ func dataSourceVSphereVirtualMachine() *schema.Resource {
 				Optional:    true,
 				Description: "Enable logging on this virtual machine.",
 			},
+			"custom_attributes_include": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A list of custom attribute names to limit custom_attributes to. If unset, all custom attributes set on the virtual machine are returned.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"extra_config": {
+				Type:        schema.TypeMap,
+				Description: "A map of advanced key/value configuration data, such as guestinfo.* keys, read from the virtual machine's extra configuration.",
+				Computed:    true,
+			},
+			"extra_config_include": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A list of extra configuration keys to limit extra_config to. If unset, all extra configuration keys are returned.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
 			"firmware": {
 				Type:        schema.TypeString,
 				Description: "The firmware type for this virtual machine.",
@@ -79,6 +186,66 @@ func dataSourceVSphereVirtualMachine() *schema.Resource {
 				Description: "The guest ID of the virtual machine.",
 				Computed:    true,
 			},
+			"guest_hostname": {
+				Type:        schema.TypeString,
+				Description: "The hostname reported by VMware Tools running in the guest.",
+				Computed:    true,
+			},
+			"guest_ip_addresses": {
+				Type:        schema.TypeList,
+				Description: "A list of IP addresses reported by VMware Tools running in the guest, across all network interfaces.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"default_ip_address": {
+				Type:        schema.TypeString,
+				Description: "The IP address reported by VMware Tools that is used to connect to the virtual machine, following the same selection order as VMware Tools uses to report a primary address.",
+				Computed:    true,
+			},
+			"guest_network_interfaces": {
+				Type:        schema.TypeList,
+				Description: "The live network configuration of each NIC reported by VMware Tools running in the guest, correlated to network_interfaces via mac_address.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mac_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"connected": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"ipv4_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ipv4_prefix_length": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"ipv6_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ipv6_prefix_length": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"ip_addresses": {
+							Type:        schema.TypeList,
+							Description: "All IP addresses (IPv4 and IPv6) reported by VMware Tools for this network interface, in the order reported. ipv4_address and ipv6_address above report only the first address of each family.",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"dns_server_list": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
 			"memory": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -91,9 +258,34 @@ func dataSourceVSphereVirtualMachine() *schema.Resource {
 				Description: "Allow memory to be added to this virtual machine while it is running.",
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "The name or path of the virtual machine.",
-				Required:    true,
+				Type:          schema.TypeString,
+				Description:   "The name or path of the virtual machine. Required unless uuid, instance_uuid, moid, or inventory_path is set.",
+				Optional:      true,
+				ConflictsWith: []string{"uuid", "instance_uuid", "moid", "inventory_path"},
+			},
+			"uuid": {
+				Type:          schema.TypeString,
+				Description:   "The BIOS UUID of the virtual machine. Guaranteed unique across a vCenter Server, unlike name.",
+				Optional:      true,
+				ConflictsWith: []string{"name", "instance_uuid", "moid", "inventory_path"},
+			},
+			"instance_uuid": {
+				Type:          schema.TypeString,
+				Description:   "The instance UUID of the virtual machine, assigned by vCenter Server and stable across clones and relocations.",
+				Optional:      true,
+				ConflictsWith: []string{"name", "uuid", "moid", "inventory_path"},
+			},
+			"moid": {
+				Type:          schema.TypeString,
+				Description:   "The managed object ID of the virtual machine, such as vm-123.",
+				Optional:      true,
+				ConflictsWith: []string{"name", "uuid", "instance_uuid", "inventory_path"},
+			},
+			"inventory_path": {
+				Type:          schema.TypeString,
+				Description:   "The inventory path of the virtual machine, such as /dc1/vm/folder/my-vm.",
+				Optional:      true,
+				ConflictsWith: []string{"name", "uuid", "instance_uuid", "moid"},
 			},
 			"network_interface_types": {
 				Type:        schema.TypeList,
@@ -178,9 +370,49 @@ func dataSourceVSphereVirtualMachine() *schema.Resource {
 				Computed:    true,
 				Description: "The common SCSI bus type of all controllers on the virtual machine.",
 			},
+			"is_template": {
+				Type:        schema.TypeBool,
+				Description: "Whether or not this virtual machine is marked as a template.",
+				Computed:    true,
+			},
+			"snapshot_id": {
+				Type:        schema.TypeString,
+				Description: "The managed object ID of the virtual machine's most recent snapshot, if any.",
+				Computed:    true,
+			},
+			"clone_spec": {
+				Type:        schema.TypeList,
+				Description: "A summary of this virtual machine's identity and hardware shape: template_uuid, guest_os_family, disk_count, and network_interface_count. These are informational values only - they are not a drop-in substitute for a vsphere_virtual_machine resource's clone block, whose fields (template_uuid, linked_clone, timeout, customize, sdrs_automation_level, disk, source_vcenter) only partially overlap and cannot be populated by assigning this list to the clone block directly.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"template_uuid": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The UUID to use as clone.template_uuid. Only populated when is_template is true.",
+						},
+						"guest_os_family": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The detected guest OS family of guest_id, one of linux, windows, or other.",
+						},
+						"disk_count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The number of disks on the source virtual machine or template.",
+						},
+						"network_interface_count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The number of network interfaces on the source virtual machine or template.",
+						},
+					},
+				},
+			},
 		},
 	}
 	structure.MergeSchema(r.Schema, schemaVirtualMachineResourceAllocation())
+	structure.MergeSchema(r.Schema, customattribute.ConfigDataSource())
 
 	return r
 }
@@ -189,7 +421,9 @@ func dataSourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{
 	client := meta.(*VSphereClient).vimClient
 
 	name := d.Get("name").(string)
-	log.Printf("[DEBUG] Looking for VM or template by name/path %q", name)
+	if name == "" && !hasVirtualMachineIdentifier(d) {
+		return fmt.Errorf("one of name, uuid, instance_uuid, moid, or inventory_path must be set")
+	}
 	var dc *object.Datacenter
 	if dcID, ok := d.GetOk("datacenter_id"); ok {
 		var err error
@@ -199,7 +433,8 @@ func dataSourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{
 		}
 		log.Printf("[DEBUG] Datacenter for VM/template search: %s", dc.InventoryPath)
 	}
-	vm, err := virtualmachine.FromPath(client, name, dc)
+
+	vm, err := resolveVirtualMachine(d, client, dc, name)
 	if err != nil {
 		return fmt.Errorf("error fetching virtual machine: %s", err)
 	}
@@ -230,11 +465,19 @@ func dataSourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{
 	d.Set("num_cpus", props.Config.Hardware.NumCPU)
 	d.Set("scsi_type", virtualdevice.ReadSCSIBusType(object.VirtualDeviceList(props.Config.Hardware.Device), d.Get("scsi_controller_scan_count").(int)))
 	d.Set("scsi_bus_sharing", virtualdevice.ReadSCSIBusSharing(object.VirtualDeviceList(props.Config.Hardware.Device), d.Get("scsi_controller_scan_count").(int)))
+	d.Set("is_template", props.Config.Template)
+	d.Set("snapshot_id", latestSnapshotID(props.Snapshot))
 
-	disks, err := virtualdevice.ReadDiskAttrsForDataSource(object.VirtualDeviceList(props.Config.Hardware.Device), d.Get("scsi_controller_scan_count").(int))
+	disks, err := virtualdevice.ReadDiskAttrsForDataSource(object.VirtualDeviceList(props.Config.Hardware.Device), d.Get("scsi_controller_scan_count").(int), d.Get("disk_scan_mode").(string))
 	if err != nil {
 		return fmt.Errorf("error reading disk sizes: %s", err)
 	}
+	if err := d.Set("disk_count", len(disks)); err != nil {
+		return fmt.Errorf("error setting disk_count: %s", err)
+	}
+	if err := d.Set("disk_controller_summary", summarizeDiskControllers(disks)); err != nil {
+		return fmt.Errorf("error setting disk_controller_summary: %s", err)
+	}
 	nics, err := virtualdevice.ReadNetworkInterfaceTypes(object.VirtualDeviceList(props.Config.Hardware.Device))
 	if err != nil {
 		return fmt.Errorf("error reading network interface types: %s", err)
@@ -252,12 +495,289 @@ func dataSourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{
 	if d.Set("network_interfaces", networkInterfaces); err != nil {
 		return fmt.Errorf("error setting network interfaces: %s", err)
 	}
+
+	cloneSpec := flattenCloneSpec(props, len(disks), len(networkInterfaces))
+	if err := d.Set("clone_spec", cloneSpec); err != nil {
+		return fmt.Errorf("error setting clone_spec: %s", err)
+	}
+
 	if err := flattenVirtualMachineResourceAllocation(d, props.Config.CpuAllocation, "cpu"); err != nil {
 		return fmt.Errorf("error setting cpu share allocation and limit: %s", err)
 	}
 	if err := flattenVirtualMachineResourceAllocation(d, props.Config.MemoryAllocation, "memory"); err != nil {
 		return fmt.Errorf("error setting memory share allocation and limit: %s", err)
 	}
+
+	if err := flattenGuestNetwork(d, props.Guest); err != nil {
+		return fmt.Errorf("error setting guest network attributes: %s", err)
+	}
+
+	extraConfig := flattenExtraConfig(props.Config.ExtraConfig, d.Get("extra_config_include").([]interface{}))
+	if err := d.Set("extra_config", extraConfig); err != nil {
+		return fmt.Errorf("error setting extra_config: %s", err)
+	}
+
+	attrs := customattribute.ReadFromResource(props.CustomValue)
+	attrs, err = filterCustomAttributes(client, attrs, d.Get("custom_attributes_include").([]interface{}))
+	if err != nil {
+		return fmt.Errorf("error filtering custom attributes: %s", err)
+	}
+	if err := d.Set("custom_attributes", attrs); err != nil {
+		return fmt.Errorf("error setting custom_attributes: %s", err)
+	}
+
 	log.Printf("[DEBUG] VM search for %q completed successfully (UUID %q)", name, props.Config.Uuid)
 	return nil
 }
+
+// flattenExtraConfig turns a virtual machine's ExtraConfig option values into
+// a map[string]string, optionally narrowed to the keys in include.
+func flattenExtraConfig(ec []types.BaseOptionValue, include []interface{}) map[string]string {
+	var keep map[string]struct{}
+	if len(include) > 0 {
+		keep = make(map[string]struct{}, len(include))
+		for _, k := range include {
+			keep[k.(string)] = struct{}{}
+		}
+	}
+	m := make(map[string]string)
+	for _, ov := range ec {
+		opt := ov.GetOptionValue()
+		if keep != nil {
+			if _, ok := keep[opt.Key]; !ok {
+				continue
+			}
+		}
+		if v, ok := opt.Value.(string); ok {
+			m[opt.Key] = v
+		}
+	}
+	return m
+}
+
+// filterCustomAttributes narrows a map of custom attribute values (keyed by
+// field key, as returned by customattribute.ReadFromResource) down to the
+// attributes whose name appears in include. When include is empty, attrs is
+// returned unchanged.
+func filterCustomAttributes(client *govmomi.Client, attrs map[string]string, include []interface{}) (map[string]string, error) {
+	if len(include) < 1 {
+		return attrs, nil
+	}
+	fm, err := object.GetCustomFieldsManager(client.Client)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := fm.Field(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	nameToKey := make(map[string]string, len(fields))
+	for _, f := range fields {
+		nameToKey[f.Name] = strconv.Itoa(int(f.Key))
+	}
+
+	return filterAttributesByName(attrs, include, nameToKey), nil
+}
+
+// filterAttributesByName is the pure part of filterCustomAttributes: given
+// the custom attribute values keyed by field key, the list of names to keep,
+// and the name-to-key mapping for the custom fields defined on the vCenter
+// Server, it returns the subset of attrs whose field name is in include.
+// Split out from filterCustomAttributes so it can be tested without a
+// *govmomi.Client.
+func filterAttributesByName(attrs map[string]string, include []interface{}, nameToKey map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for _, name := range include {
+		key, ok := nameToKey[name.(string)]
+		if !ok {
+			continue
+		}
+		if v, ok := attrs[key]; ok {
+			filtered[key] = v
+		}
+	}
+	return filtered
+}
+
+// latestSnapshotID returns the managed object ID of a virtual machine's most
+// recent snapshot, preferring the current snapshot pointer vSphere maintains
+// and falling back to nothing if the VM has no snapshots.
+func latestSnapshotID(snapshot *types.VirtualMachineSnapshotInfo) string {
+	if snapshot == nil || snapshot.CurrentSnapshot == nil {
+		return ""
+	}
+	return snapshot.CurrentSnapshot.Value
+}
+
+// guestOSFamily makes a best-effort guess at whether a vSphere guest ID
+// belongs to the linux or windows family, for use in clone_spec. Guest IDs
+// that match neither pattern are reported as other.
+//
+// This is deliberately a standalone string heuristic rather than a call to
+// resourcepool.OSFamily: that function classifies guest IDs by querying a
+// resource pool's environment browser for its GuestOsDescriptor list, but a
+// vsphere_virtual_machine data source lookup has no resource pool argument
+// to query against, only the already-configured guest_id of an existing VM.
+func guestOSFamily(guestID string) string {
+	lower := strings.ToLower(guestID)
+	switch {
+	case strings.Contains(lower, "darwin"):
+		// Checked before the "win" case below: "darwin" contains "win" as a
+		// substring, and macOS guest IDs are not part of the windows family.
+		return "other"
+	case strings.Contains(lower, "win"):
+		return "windows"
+	case strings.Contains(lower, "linux"), strings.Contains(lower, "ubuntu"), strings.Contains(lower, "centos"),
+		strings.Contains(lower, "rhel"), strings.Contains(lower, "debian"), strings.Contains(lower, "suse"),
+		strings.Contains(lower, "coreos"), strings.Contains(lower, "photon"):
+		return "linux"
+	default:
+		return "other"
+	}
+}
+
+// flattenCloneSpec summarizes a virtual machine's identity and hardware
+// shape for clone_spec: template_uuid, guest_os_family, disk_count, and
+// network_interface_count. Only template_uuid is also a field of
+// vsphere_virtual_machine's clone sub-resource; the result does not have that
+// sub-resource's shape and cannot be assigned to a clone block as a whole.
+func flattenCloneSpec(props *mo.VirtualMachine, diskCount, nicCount int) []map[string]interface{} {
+	var templateUUID string
+	if props.Config.Template {
+		templateUUID = props.Config.Uuid
+	}
+	return []map[string]interface{}{
+		{
+			"template_uuid":           templateUUID,
+			"guest_os_family":         guestOSFamily(props.Config.GuestId),
+			"disk_count":              diskCount,
+			"network_interface_count": nicCount,
+		},
+	}
+}
+
+// flattenGuestNetwork reads the live network state reported by VMware Tools
+// into guest_network_interfaces, guest_hostname, guest_ip_addresses, and
+// default_ip_address. If VMware Tools is not running, the guest info is
+// absent and all of these are left at their zero values.
+func flattenGuestNetwork(d *schema.ResourceData, guest *types.GuestInfo) error {
+	if guest == nil {
+		return nil
+	}
+	if err := d.Set("guest_hostname", guest.HostName); err != nil {
+		return err
+	}
+	if guest.IpAddress != "" {
+		if err := d.Set("default_ip_address", guest.IpAddress); err != nil {
+			return err
+		}
+	}
+
+	var ifaces []map[string]interface{}
+	var addrs []string
+	for _, nic := range guest.Net {
+		iface := map[string]interface{}{
+			"mac_address": nic.MacAddress,
+			"connected":   nic.Connected,
+		}
+		var nicAddrs []string
+		if nic.IpConfig != nil {
+			for _, addr := range nic.IpConfig.IpAddress {
+				addrs = append(addrs, addr.IpAddress)
+				nicAddrs = append(nicAddrs, addr.IpAddress)
+				if net.ParseIP(addr.IpAddress).To4() != nil {
+					if _, ok := iface["ipv4_address"]; !ok {
+						iface["ipv4_address"] = addr.IpAddress
+						iface["ipv4_prefix_length"] = int(addr.PrefixLength)
+					}
+				} else {
+					if _, ok := iface["ipv6_address"]; !ok {
+						iface["ipv6_address"] = addr.IpAddress
+						iface["ipv6_prefix_length"] = int(addr.PrefixLength)
+					}
+				}
+			}
+		}
+		iface["ip_addresses"] = nicAddrs
+		// NicInfo carries its own DnsConfig, scoped to that interface -
+		// guest.IpStack is a separate list of the guest's IP stacks (usually
+		// just one) and is not keyed by NIC, so it cannot be used to
+		// correlate DNS servers to a specific interface.
+		if nic.DnsConfig != nil {
+			iface["dns_server_list"] = nic.DnsConfig.IpAddress
+		}
+		ifaces = append(ifaces, iface)
+	}
+	if err := d.Set("guest_network_interfaces", ifaces); err != nil {
+		return err
+	}
+	return d.Set("guest_ip_addresses", addrs)
+}
+
+// hasVirtualMachineIdentifier returns true if any of the alternate lookup
+// arguments to uuid, instance_uuid, moid, or inventory_path were set.
+func hasVirtualMachineIdentifier(d *schema.ResourceData) bool {
+	for _, k := range []string{"uuid", "instance_uuid", "moid", "inventory_path"} {
+		if _, ok := d.GetOk(k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveVirtualMachine dispatches to the appropriate virtualmachine lookup
+// helper based on whichever one of uuid, instance_uuid, moid,
+// inventory_path, or name (the default) was set on the data source.
+func resolveVirtualMachine(d *schema.ResourceData, client *govmomi.Client, dc *object.Datacenter, name string) (*object.VirtualMachine, error) {
+	if v, ok := d.GetOk("uuid"); ok {
+		log.Printf("[DEBUG] Looking for VM or template by UUID %q", v)
+		return virtualmachine.FromUUID(client, v.(string))
+	}
+	if v, ok := d.GetOk("instance_uuid"); ok {
+		log.Printf("[DEBUG] Looking for VM or template by instance UUID %q", v)
+		return virtualmachine.FromInstanceUUID(client, v.(string))
+	}
+	if v, ok := d.GetOk("moid"); ok {
+		log.Printf("[DEBUG] Looking for VM or template by managed object ID %q", v)
+		return virtualmachine.FromMOID(client, v.(string))
+	}
+	if v, ok := d.GetOk("inventory_path"); ok {
+		log.Printf("[DEBUG] Looking for VM or template by inventory path %q", v)
+		return virtualmachine.FromInventoryPath(client, v.(string))
+	}
+	log.Printf("[DEBUG] Looking for VM or template by name/path %q", name)
+	return virtualmachine.FromPath(client, name, dc)
+}
+
+// summarizeDiskControllers groups the flattened disk attributes produced by
+// virtualdevice.ReadDiskAttrsForDataSource by controller_type and
+// controller_bus_number, producing a disk count per controller so that
+// consumers can tell how disks are spread across multiple controllers
+// without walking the full disks list themselves.
+func summarizeDiskControllers(disks []map[string]interface{}) []map[string]interface{} {
+	type controllerKey struct {
+		controllerType string
+		busNumber      int
+	}
+	order := make([]controllerKey, 0)
+	counts := make(map[controllerKey]int)
+	for _, disk := range disks {
+		key := controllerKey{
+			controllerType: disk["controller_type"].(string),
+			busNumber:      disk["controller_bus_number"].(int),
+		}
+		if _, ok := counts[key]; !ok {
+			order = append(order, key)
+		}
+		counts[key]++
+	}
+	summary := make([]map[string]interface{}, 0, len(order))
+	for _, key := range order {
+		summary = append(summary, map[string]interface{}{
+			"controller_type": key.controllerType,
+			"bus_number":      key.busNumber,
+			"disk_count":      counts[key],
+		})
+	}
+	return summary
+}
@@ -0,0 +1,126 @@
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestSummarizeDiskControllers(t *testing.T) {
+	disks := []map[string]interface{}{
+		{"controller_type": "scsi", "controller_bus_number": 0},
+		{"controller_type": "scsi", "controller_bus_number": 0},
+		{"controller_type": "sata", "controller_bus_number": 0},
+		{"controller_type": "scsi", "controller_bus_number": 1},
+	}
+
+	got := summarizeDiskControllers(disks)
+	want := []map[string]interface{}{
+		{"controller_type": "scsi", "bus_number": 0, "disk_count": 2},
+		{"controller_type": "sata", "bus_number": 0, "disk_count": 1},
+		{"controller_type": "scsi", "bus_number": 1, "disk_count": 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("summarizeDiskControllers(%v) = %v, want %v", disks, got, want)
+	}
+}
+
+func TestSummarizeDiskControllersNoDisks(t *testing.T) {
+	got := summarizeDiskControllers(nil)
+	if len(got) != 0 {
+		t.Fatalf("expected no controller summaries for no disks, got %v", got)
+	}
+}
+
+func TestFilterAttributesByName(t *testing.T) {
+	nameToKey := map[string]string{
+		"department": "100",
+		"owner":      "101",
+	}
+	attrs := map[string]string{
+		"100": "engineering",
+		"101": "alice",
+		"102": "unrelated",
+	}
+
+	got := filterAttributesByName(attrs, []interface{}{"owner"}, nameToKey)
+	want := map[string]string{"101": "alice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("filterAttributesByName() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterAttributesByNameUnknownName(t *testing.T) {
+	nameToKey := map[string]string{"owner": "101"}
+	attrs := map[string]string{"101": "alice"}
+
+	got := filterAttributesByName(attrs, []interface{}{"does-not-exist"}, nameToKey)
+	if len(got) != 0 {
+		t.Fatalf("expected no attributes for an unknown custom attribute name, got %v", got)
+	}
+}
+
+func TestGuestOSFamily(t *testing.T) {
+	cases := []struct {
+		guestID string
+		want    string
+	}{
+		{"windows9Server64Guest", "windows"},
+		{"ubuntu64Guest", "linux"},
+		{"rhel8_64Guest", "linux"},
+		{"darwin19_64Guest", "other"},
+		{"otherGuest64", "other"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.guestID, func(t *testing.T) {
+			if got := guestOSFamily(tc.guestID); got != tc.want {
+				t.Fatalf("guestOSFamily(%q) = %q, want %q", tc.guestID, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFlattenCloneSpec(t *testing.T) {
+	props := &mo.VirtualMachine{
+		Config: &types.VirtualMachineConfigInfo{
+			Uuid:     "42300000-0000-0000-0000-000000000000",
+			GuestId:  "ubuntu64Guest",
+			Template: true,
+		},
+	}
+
+	got := flattenCloneSpec(props, 2, 1)
+	want := []map[string]interface{}{
+		{
+			"template_uuid":           "42300000-0000-0000-0000-000000000000",
+			"guest_os_family":         "linux",
+			"disk_count":              2,
+			"network_interface_count": 1,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("flattenCloneSpec() = %v, want %v", got, want)
+	}
+}
+
+func TestFlattenCloneSpecNotATemplate(t *testing.T) {
+	props := &mo.VirtualMachine{
+		Config: &types.VirtualMachineConfigInfo{
+			Uuid:     "42300000-0000-0000-0000-000000000000",
+			GuestId:  "windows9Server64Guest",
+			Template: false,
+		},
+	}
+
+	got := flattenCloneSpec(props, 0, 0)
+	if got[0]["template_uuid"] != "" {
+		t.Fatalf("expected empty template_uuid for a non-template VM, got %v", got[0]["template_uuid"])
+	}
+	if got[0]["guest_os_family"] != "windows" {
+		t.Fatalf("expected windows guest_os_family, got %v", got[0]["guest_os_family"])
+	}
+}
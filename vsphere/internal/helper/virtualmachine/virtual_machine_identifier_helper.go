@@ -0,0 +1,68 @@
+package virtualmachine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/provider"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// FromInstanceUUID locates a virtual machine by its VMware-internal instance
+// UUID, which is distinct from the BIOS/SMBIOS UUID that FromUUID resolves
+// and stays stable across operations - such as a cross-vCenter migration -
+// that can change the BIOS UUID.
+func FromInstanceUUID(client *govmomi.Client, uuid string) (*object.VirtualMachine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	search := object.NewSearchIndex(client.Client)
+	instanceUUID := true
+	ref, err := search.FindByUuid(ctx, nil, uuid, true, &instanceUUID)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for virtual machine by instance UUID %q: %s", uuid, err)
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("virtual machine with instance UUID %q not found", uuid)
+	}
+	return object.NewVirtualMachine(client.Client, ref.Reference()), nil
+}
+
+// FromMOID locates a virtual machine directly from its managed object ID,
+// without a SearchIndex round-trip to resolve it from some other identifier
+// first. The moref's existence is confirmed with a property fetch, since
+// constructing an object.VirtualMachine from an arbitrary moref never fails
+// on its own.
+func FromMOID(client *govmomi.Client, moid string) (*object.VirtualMachine, error) {
+	ref := types.ManagedObjectReference{
+		Type:  "VirtualMachine",
+		Value: moid,
+	}
+	vm := object.NewVirtualMachine(client.Client, ref)
+	if _, err := Properties(vm); err != nil {
+		return nil, fmt.Errorf("error locating virtual machine with managed object ID %q: %s", moid, err)
+	}
+	return vm, nil
+}
+
+// FromInventoryPath locates a virtual machine by its absolute inventory path
+// (for example "/my-datacenter/vm/my-folder/my-vm"), which lets a
+// configuration pin an exact VM even when its display name is not unique.
+func FromInventoryPath(client *govmomi.Client, path string) (*object.VirtualMachine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	search := object.NewSearchIndex(client.Client)
+	ref, err := search.FindByInventoryPath(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for virtual machine by inventory path %q: %s", path, err)
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("virtual machine at inventory path %q not found", path)
+	}
+	vm, ok := ref.(*object.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("inventory path %q does not refer to a virtual machine", path)
+	}
+	return vm, nil
+}
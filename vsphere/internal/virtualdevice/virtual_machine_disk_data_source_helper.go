@@ -0,0 +1,110 @@
+package virtualdevice
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ReadDiskAttrsForDataSource flattens the virtual disks in a device list into
+// the shape expected by vsphere_virtual_machine's disks attribute, one entry
+// per disk, sorted by controller bus number and then unit number.
+//
+// scanCount limits how many SCSI controller buses are considered, the same
+// way scsi_controller_scan_count limits SCSI bus detection elsewhere. When
+// scanMode is "all_controllers", disks on IDE, SATA, and NVMe controllers are
+// included as well as the scanCount-limited SCSI buses; when scanMode is
+// "scsi_only", only the scanCount-limited SCSI buses are considered.
+func ReadDiskAttrsForDataSource(l object.VirtualDeviceList, scanCount int, scanMode string) ([]map[string]interface{}, error) {
+	var disks []map[string]interface{}
+	for _, device := range l.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+
+		ctlrType, busNumber, err := diskControllerTypeAndBus(l, disk.ControllerKey)
+		if err != nil {
+			return nil, err
+		}
+		if ctlrType == "scsi" {
+			if busNumber >= scanCount {
+				continue
+			}
+		} else if scanMode != "all_controllers" {
+			continue
+		}
+
+		var unitNumber int
+		if disk.UnitNumber != nil {
+			unitNumber = int(*disk.UnitNumber)
+		}
+
+		var label string
+		if disk.DeviceInfo != nil {
+			label = disk.DeviceInfo.GetDescription().Label
+		}
+
+		attrs := map[string]interface{}{
+			"size":                  int(disk.CapacityInKB / 1024 / 1024),
+			"controller_type":       ctlrType,
+			"controller_bus_number": busNumber,
+			"unit_number":           unitNumber,
+			"label":                 label,
+			"io_limit":              -1,
+			"io_reservation":        0,
+		}
+
+		if backing, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+			attrs["eagerly_scrub"] = backing.EagerlyScrub != nil && *backing.EagerlyScrub
+			attrs["thin_provisioned"] = backing.ThinProvisioned != nil && *backing.ThinProvisioned
+			attrs["disk_mode"] = backing.DiskMode
+			attrs["write_through"] = backing.WriteThrough != nil && *backing.WriteThrough
+			attrs["sharing"] = backing.Sharing
+			if backing.Datastore != nil {
+				attrs["datastore_id"] = backing.Datastore.Value
+			}
+		}
+
+		if alloc := disk.StorageIOAllocation; alloc != nil {
+			if alloc.Limit != nil {
+				attrs["io_limit"] = int(*alloc.Limit)
+			}
+			attrs["io_reservation"] = int(alloc.Reservation)
+		}
+
+		disks = append(disks, attrs)
+	}
+
+	sort.SliceStable(disks, func(i, j int) bool {
+		bi, bj := disks[i]["controller_bus_number"].(int), disks[j]["controller_bus_number"].(int)
+		if bi != bj {
+			return bi < bj
+		}
+		return disks[i]["unit_number"].(int) < disks[j]["unit_number"].(int)
+	})
+
+	return disks, nil
+}
+
+// diskControllerTypeAndBus resolves a virtual disk's ControllerKey to the
+// controller_type and controller_bus_number reported for it, so that disks
+// on IDE, SATA, and NVMe controllers can be reported alongside the
+// historically SCSI-only disks attribute.
+func diskControllerTypeAndBus(l object.VirtualDeviceList, key int32) (string, int, error) {
+	device := l.FindByKey(key)
+	if device == nil {
+		return "", 0, fmt.Errorf("could not find disk controller device with key %d", key)
+	}
+	switch ctlr := device.(type) {
+	case *types.VirtualSCSIController:
+		return "scsi", int(ctlr.BusNumber), nil
+	case *types.VirtualIDEController:
+		return "ide", int(ctlr.BusNumber), nil
+	case *types.VirtualSATAController:
+		return "sata", int(ctlr.BusNumber), nil
+	case *types.VirtualNVMEController:
+		return "nvme", int(ctlr.BusNumber), nil
+	default:
+		return "", 0, fmt.Errorf("disk controller device with key %d has unsupported type %T", key, device)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
@@ -12,14 +14,40 @@ import (
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/network"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/provider"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/resourcepool"
+	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/virtualdevice"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/guest"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
+// sdrsAutomationLevelAllowedValues are the allowed values for the
+// sdrs_automation_level field on the clone sub-resource.
+var sdrsAutomationLevelAllowedValues = []string{
+	"automated",
+	"manual",
+}
+
+// cloneDiskModeAllowedValues are the allowed values for the disk_mode field
+// on the clone.disk sub-resource.
+var cloneDiskModeAllowedValues = []string{
+	string(types.VirtualDiskModePersistent),
+	string(types.VirtualDiskModeIndependent_persistent),
+	string(types.VirtualDiskModeIndependent_nonpersistent),
+}
+
+// cloneDiskSharingAllowedValues are the allowed values for the sharing field
+// on the clone.disk sub-resource.
+var cloneDiskSharingAllowedValues = []string{
+	string(types.VirtualDiskSharingSharingNone),
+	string(types.VirtualDiskSharingSharingMultiWriter),
+}
+
 // VirtualMachineCloneSchema represents the schema for the VM clone sub-resource.
 //
 // This is a workflow for vsphere_virtual_machine that facilitates the creation
@@ -51,6 +79,135 @@ func VirtualMachineCloneSchema() map[string]*schema.Schema {
 			Description: "The customization spec for this clone. This allows the user to configure the virtual machine post-clone.",
 			Elem:        &schema.Resource{Schema: VirtualMachineCustomizeSchema()},
 		},
+		"sdrs_automation_level": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "automated",
+			Description:  "The SDRS automation level to use when the source datastore_cluster_id resolves through Storage DRS. When set to manual, the recommended datastore is not applied automatically and is instead surfaced in sdrs_recommendations for inspection.",
+			ValidateFunc: validation.StringInSlice(sdrsAutomationLevelAllowedValues, false),
+		},
+		"disk": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Used to customize the placement and format of individual disks on the cloned virtual machine. Each entry is keyed by the unit number of the corresponding disk on the source virtual machine or template.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"unit_number": {
+						Type:        schema.TypeInt,
+						Required:    true,
+						Description: "The unit number of the disk on the source virtual machine or template that this override applies to.",
+					},
+					"label": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "The label of the disk on the source virtual machine or template. Used for documentation purposes only, unit_number is used to locate the disk.",
+					},
+					"datastore_id": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "The managed object ID of the datastore to place this disk's VMDK in, overriding the clone's default datastore_id or datastore_cluster_id.",
+					},
+					"disk_mode": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      string(types.VirtualDiskModePersistent),
+						Description:  "The mode of this disk, to override the default of persistent. Can be one of persistent, independent_persistent, or independent_nonpersistent.",
+						ValidateFunc: validation.StringInSlice(cloneDiskModeAllowedValues, false),
+					},
+					"eagerly_scrub": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Zero out the contents of this disk immediately after cloning, rather than lazily as it is used. Cannot be used alongside thin_provisioned.",
+					},
+					"thin_provisioned": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Thin provision this disk, overriding the format it otherwise would have been cloned with. Cannot be used alongside eagerly_scrub.",
+					},
+					"sharing": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      string(types.VirtualDiskSharingSharingNone),
+						Description:  "The sharing mode of this disk, to override the default of sharingNone. Can be one of sharingNone or sharingMultiWriter.",
+						ValidateFunc: validation.StringInSlice(cloneDiskSharingAllowedValues, false),
+					},
+				},
+			},
+		},
+		"source_vcenter": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "Connection details for the vCenter Server that holds the source template, when it differs from the vCenter Server targeted by the provider. Enables cross-vCenter clones (XVC).",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"host": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The FQDN or IP address of the source vCenter Server.",
+					},
+					"user": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The user to authenticate to the source vCenter Server as.",
+					},
+					"password": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Sensitive:   true,
+						Description: "The password to authenticate to the source vCenter Server with.",
+					},
+					"thumbprint": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The SHA-1 thumbprint of the source vCenter Server's SSL certificate.",
+					},
+					"insecure": {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Do not validate the source vCenter Server's SSL certificate.",
+					},
+				},
+			},
+		},
+	}
+}
+
+// VirtualMachineSDRSRecommendationsSchema represents the schema for the
+// Storage DRS recommendations computed attributes on vsphere_virtual_machine.
+//
+// This is surfaced as a top-level resource attribute rather than nested under
+// clone, since clone is a TypeList and the field writer used by helper/schema
+// rejects partial writes into a list -- it must be merged into the resource's
+// top-level schema with structure.MergeSchema.
+func VirtualMachineSDRSRecommendationsSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"sdrs_recommendations": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The ranked list of Storage DRS datastore recommendations returned for this clone when sdrs_automation_level is manual.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"key": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"datastore_id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"rating": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"reason": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -72,6 +229,13 @@ func VirtualMachineInstantCloneSchema() map[string]*schema.Schema {
 			Description:  "The timeout, in minutes, to wait for the virtual machine clone to complete.",
 			ValidateFunc: validation.IntAtLeast(10),
 		},
+		"customize": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "The customization spec for this instant clone. Unlike a regular clone, this is applied to the guest through the customization manager after the instant clone task completes, since InstantClone_Task does not accept a customization spec.",
+			Elem:        &schema.Resource{Schema: VirtualMachineCustomizeSchema()},
+		},
 	}
 }
 
@@ -85,7 +249,14 @@ func ValidateVirtualMachineClone(d *schema.ResourceDiff, c *govmomi.Client) erro
 	tUUID := d.Get("clone.0.template_uuid").(string)
 	if d.NewValueKnown("clone.0.template_uuid") {
 		log.Printf("[DEBUG] ValidateVirtualMachineClone: Validating fitness of source VM/template %s", tUUID)
-		vm, err := virtualmachine.FromUUID(c, tUUID)
+		sourceClient, err := resolveSourceVCenterClient(d, c)
+		if err != nil {
+			return fmt.Errorf("error connecting to source_vcenter: %s", err)
+		}
+		if sourceClient != c {
+			defer logoutSourceVCenterClient(sourceClient)
+		}
+		vm, err := virtualmachine.FromUUID(sourceClient, tUUID)
 		if err != nil {
 			return fmt.Errorf("cannot locate virtual machine or template with UUID %q: %s", tUUID, err)
 		}
@@ -115,6 +286,9 @@ func ValidateVirtualMachineClone(d *schema.ResourceDiff, c *govmomi.Client) erro
 		if err := virtualdevice.DiskCloneValidateOperation(d, c, l, linked); err != nil {
 			return err
 		}
+		if err := validateCloneDiskOverrides(d, l); err != nil {
+			return err
+		}
 		vconfig := vprops.Config.VAppConfig
 		if vconfig != nil {
 			// We need to set the vApp transport types here so that it is available
@@ -170,35 +344,205 @@ func validateCloneSnapshots(props *mo.VirtualMachine) error {
 	return nil
 }
 
+// cloneDataGetter is satisfied by both *schema.ResourceDiff and
+// *schema.ResourceData, letting resolveSourceVCenterClient be shared by
+// ValidateVirtualMachineClone and ExpandVirtualMachineCloneSpec.
+type cloneDataGetter interface {
+	Get(key string) interface{}
+}
+
+// resolveSourceVCenterClient returns the govmomi.Client that should be used
+// to look up the clone's source VM/template. If clone.source_vcenter is not
+// set, the provider's own client is returned unchanged. Otherwise, a new
+// client is established against the remote vCenter Server described by that
+// block, for use in cross-vCenter (XVC) clones.
+func resolveSourceVCenterClient(d cloneDataGetter, c *govmomi.Client) (*govmomi.Client, error) {
+	sv := d.Get("clone.0.source_vcenter").([]interface{})
+	if len(sv) < 1 {
+		return c, nil
+	}
+	m := sv[0].(map[string]interface{})
+	host := m["host"].(string)
+
+	u := &url.URL{
+		Scheme: "https",
+		Host:   host,
+		Path:   "/sdk",
+	}
+	u.User = url.UserPassword(m["user"].(string), m["password"].(string))
+
+	soapClient := soap.NewClient(u, m["insecure"].(bool))
+	if tp := m["thumbprint"].(string); tp != "" {
+		soapClient.SetThumbprint(u.Host, tp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to source vCenter Server %q: %s", host, err)
+	}
+	remote := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: object.NewSessionManager(vimClient),
+	}
+	if err := remote.Login(ctx, u.User); err != nil {
+		return nil, fmt.Errorf("error authenticating to source vCenter Server %q: %s", host, err)
+	}
+	return remote, nil
+}
+
+// logoutSourceVCenterClient ends the session opened by resolveSourceVCenterClient
+// against a remote source_vcenter. Callers must only invoke this on a client
+// returned when source_vcenter was actually set, never on the provider's own
+// shared client, or it will end that session as well.
+func logoutSourceVCenterClient(remote *govmomi.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	if err := remote.Logout(ctx); err != nil {
+		log.Printf("[WARN] logoutSourceVCenterClient: error logging out of source vCenter Server session: %s", err)
+	}
+}
+
+// expandServiceLocator builds the ServiceLocator that must be attached to a
+// VirtualMachineRelocateSpec to perform a cross-vCenter (XVC) clone.
+//
+// A clone from a remote source_vcenter is submitted as a CloneVM_Task
+// against the *source* VM, through the source vCenter Server's own client -
+// so the ServiceLocator has to describe the opposite end of that call: the
+// destination vCenter Server (dst, i.e. the provider's own client), telling
+// the source side how to authenticate into it in order to relocate the new
+// VM there. A locator built from source_vcenter's own connection details
+// would point the source vCenter back at itself and clone nothing across.
+func expandServiceLocator(dst *govmomi.Client) (*types.ServiceLocator, error) {
+	u := dst.URL()
+	if u == nil || u.User == nil {
+		return nil, fmt.Errorf("cannot build service locator: destination vCenter Server client has no credentials available")
+	}
+	password, _ := u.User.Password()
+
+	return &types.ServiceLocator{
+		InstanceUuid:  dst.ServiceContent.About.InstanceUuid,
+		SslThumbprint: dst.Thumbprint(u.Host),
+		Url:           fmt.Sprintf("https://%s/sdk", u.Host),
+		Credential: &types.ServiceLocatorNamePassword{
+			Username: u.User.Username(),
+			Password: password,
+		},
+	}, nil
+}
+
+// validateCloneDiskOverrides checks that each entry in clone.disk refers to a
+// disk that actually exists on the source VM/template by unit number, and
+// that the eagerly_scrub and thin_provisioned flags are not both set, which
+// is an invalid combination for a virtual disk.
+func validateCloneDiskOverrides(d *schema.ResourceDiff, l object.VirtualDeviceList) error {
+	disks := d.Get("clone.0.disk").([]interface{})
+	if len(disks) < 1 {
+		return nil
+	}
+	units := make(map[int32]struct{})
+	for _, device := range l.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		if disk.UnitNumber != nil {
+			units[*disk.UnitNumber] = struct{}{}
+		}
+	}
+	return validateCloneDiskUnitNumbers(disks, units)
+}
+
+// validateCloneDiskUnitNumbers is the pure part of validateCloneDiskOverrides:
+// given the clone.disk entries and the set of unit numbers that actually
+// exist on the source virtual machine or template, it checks that every
+// override targets a disk that exists and that no override combines
+// eagerly_scrub and thin_provisioned, which vSphere rejects for a single
+// disk. Split out from validateCloneDiskOverrides so it can be tested
+// without a *schema.ResourceDiff.
+func validateCloneDiskUnitNumbers(disks []interface{}, units map[int32]struct{}) error {
+	for _, di := range disks {
+		dm := di.(map[string]interface{})
+		unit := int32(dm["unit_number"].(int))
+		if _, ok := units[unit]; !ok {
+			return fmt.Errorf("clone.disk: no disk with unit_number %d found on source virtual machine or template", unit)
+		}
+		if dm["eagerly_scrub"].(bool) && dm["thin_provisioned"].(bool) {
+			return fmt.Errorf("clone.disk: unit_number %d: eagerly_scrub and thin_provisioned cannot both be true", unit)
+		}
+	}
+	return nil
+}
+
 // ExpandVirtualMachineCloneSpec creates a clone spec for an existing virtual machine.
 //
 // The clone spec built by this function for the clone contains the target
 // datastore, the source snapshot in the event of linked clones, and a relocate
 // spec that contains the new locations and configuration details of the new
 // virtual disks.
-func ExpandVirtualMachineCloneSpec(d *schema.ResourceData, c *govmomi.Client) (types.VirtualMachineCloneSpec, *object.VirtualMachine, error) {
+//
+// The returned *object.VirtualMachine is bound to the source_vcenter client
+// when one is configured, not to c, since the clone task has to be submitted
+// through that client. The caller must defer the returned cleanup func only
+// after it has submitted the clone task against that VM - logging out the
+// source_vcenter session any earlier would invalidate the task submission.
+// When no source_vcenter is configured, cleanup is a no-op and the returned
+// VM is bound to c as usual.
+func ExpandVirtualMachineCloneSpec(d *schema.ResourceData, c *govmomi.Client) (types.VirtualMachineCloneSpec, *object.VirtualMachine, []interface{}, func(), error) {
 	var spec types.VirtualMachineCloneSpec
+	var sdrsRecommendations []interface{}
+	cleanup := func() {}
 	log.Printf("[DEBUG] ExpandVirtualMachineCloneSpec: Preparing clone spec for VM")
 
 	// Populate the datastore only if we have a datastore ID. The ID may not be
 	// specified in the event a datastore cluster is specified instead.
+	dsClusterID, dsClusterOK := d.GetOk("datastore_cluster_id")
 	if dsID, ok := d.GetOk("datastore_id"); ok {
 		ds, err := datastore.FromID(c, dsID.(string))
 		if err != nil {
-			return spec, nil, fmt.Errorf("error locating datastore for VM: %s", err)
+			return spec, nil, nil, cleanup, fmt.Errorf("error locating datastore for VM: %s", err)
 		}
 		spec.Location.Datastore = types.NewReference(ds.Reference())
 	}
 
+	sourceClient, err := resolveSourceVCenterClient(d, c)
+	if err != nil {
+		return spec, nil, nil, cleanup, fmt.Errorf("error connecting to source_vcenter: %s", err)
+	}
+	if sourceClient != c {
+		// Do not log out here: the clone task this spec is being built for
+		// still has to be submitted through sourceClient by the caller.
+		// Logging out as soon as this function returns would tear down the
+		// session before that submission happens. The caller is responsible
+		// for calling the returned cleanup func once the task is submitted.
+		cleanup = func() { logoutSourceVCenterClient(sourceClient) }
+
+		// This is a cross-vCenter (XVC) clone. Attach a ServiceLocator
+		// describing the destination vCenter (c), so that the clone task -
+		// submitted against the source vCenter - can authenticate into the
+		// destination in order to relocate the new VM there.
+		locator, err := expandServiceLocator(c)
+		if err != nil {
+			return spec, nil, nil, cleanup, fmt.Errorf("error building service locator for destination vCenter Server: %s", err)
+		}
+		spec.Location.Service = locator
+
+		// Storage DRS placement operates on morefs resolved against the
+		// destination vCenter's SRM. A VM/pod moref resolved against the
+		// remote source_vcenter is meaningless there, so reject the
+		// combination rather than silently targeting the wrong object.
+		if dsClusterOK {
+			return spec, nil, nil, cleanup, fmt.Errorf("datastore_cluster_id cannot be used together with source_vcenter: Storage DRS placement is not supported for cross-vCenter clones")
+		}
+	}
+
 	tUUID := d.Get("clone.0.template_uuid").(string)
 	log.Printf("[DEBUG] ExpandVirtualMachineCloneSpec: Cloning from UUID: %s", tUUID)
-	vm, err := virtualmachine.FromUUID(c, tUUID)
+	vm, err := virtualmachine.FromUUID(sourceClient, tUUID)
 	if err != nil {
-		return spec, nil, fmt.Errorf("cannot locate virtual machine or template with UUID %q: %s", tUUID, err)
+		return spec, nil, nil, cleanup, fmt.Errorf("cannot locate virtual machine or template with UUID %q: %s", tUUID, err)
 	}
 	vprops, err := virtualmachine.Properties(vm)
 	if err != nil {
-		return spec, nil, fmt.Errorf("error fetching virtual machine or template properties: %s", err)
+		return spec, nil, nil, cleanup, fmt.Errorf("error fetching virtual machine or template properties: %s", err)
 	}
 	// If we are creating a linked clone, grab the current snapshot of the
 	// source, and populate the appropriate field. This should have already been
@@ -207,7 +551,7 @@ func ExpandVirtualMachineCloneSpec(d *schema.ResourceData, c *govmomi.Client) (t
 		log.Printf("[DEBUG] ExpandVirtualMachineCloneSpec: Clone type is a linked clone")
 		log.Printf("[DEBUG] ExpandVirtualMachineCloneSpec: Fetching snapshot for VM/template UUID %s", tUUID)
 		if err := validateCloneSnapshots(vprops); err != nil {
-			return spec, nil, err
+			return spec, nil, nil, cleanup, err
 		}
 		spec.Snapshot = vprops.Snapshot.CurrentSnapshot
 		spec.Location.DiskMoveType = string(types.VirtualMachineRelocateDiskMoveOptionsCreateNewChildDiskBacking)
@@ -218,19 +562,19 @@ func ExpandVirtualMachineCloneSpec(d *schema.ResourceData, c *govmomi.Client) (t
 	poolID := d.Get("resource_pool_id").(string)
 	pool, err := resourcepool.FromID(c, poolID)
 	if err != nil {
-		return spec, nil, fmt.Errorf("could not find resource pool ID %q: %s", poolID, err)
+		return spec, nil, nil, cleanup, fmt.Errorf("could not find resource pool ID %q: %s", poolID, err)
 	}
 	var hs *object.HostSystem
 	if v, ok := d.GetOk("host_system_id"); ok {
 		hsID := v.(string)
 		var err error
 		if hs, err = hostsystem.FromID(c, hsID); err != nil {
-			return spec, nil, fmt.Errorf("error locating host system at ID %q: %s", hsID, err)
+			return spec, nil, nil, cleanup, fmt.Errorf("error locating host system at ID %q: %s", hsID, err)
 		}
 	}
 	// Validate that the host is part of the resource pool before proceeding
 	if err := resourcepool.ValidateHost(c, pool, hs); err != nil {
-		return spec, nil, err
+		return spec, nil, nil, cleanup, err
 	}
 	poolRef := pool.Reference()
 	spec.Location.Pool = &poolRef
@@ -243,11 +587,227 @@ func ExpandVirtualMachineCloneSpec(d *schema.ResourceData, c *govmomi.Client) (t
 	l := object.VirtualDeviceList(vprops.Config.Hardware.Device)
 	relocators, err := virtualdevice.DiskCloneRelocateOperation(d, c, l)
 	if err != nil {
-		return spec, nil, err
+		return spec, nil, nil, cleanup, err
 	}
 	spec.Location.Disk = relocators
+
+	// If a datastore cluster was supplied instead of a single datastore, ask
+	// Storage DRS for a recommended datastore per-disk before we submit the
+	// clone, rather than leaving Location.Datastore/Disk unset. This runs
+	// before clone.disk overrides are merged in: applyStorageDrsPlacement
+	// skips any disk that already has a locator, and applyCloneDiskOverrides
+	// needs spec.Location.Datastore already populated so it can fall back to
+	// the SDRS-selected datastore for overrides that don't specify their own.
+	if dsClusterOK {
+		sdrsRecommendations, err = applyStorageDrsPlacement(d, c, vm, l, &spec, dsClusterID.(string))
+		if err != nil {
+			return spec, nil, nil, cleanup, err
+		}
+	}
+
+	// Merge in any per-disk overrides from clone.disk. These take precedence
+	// over both the default relocate operation above and the Storage DRS
+	// recommendation, since they are explicit user intent.
+	if err := applyCloneDiskOverrides(d, c, l, &spec); err != nil {
+		return spec, nil, nil, cleanup, err
+	}
+
 	log.Printf("[DEBUG] ExpandVirtualMachineCloneSpec: Clone spec prep complete")
-	return spec, vm, nil
+	return spec, vm, sdrsRecommendations, cleanup, nil
+}
+
+// applyCloneDiskOverrides merges the per-disk overrides configured in
+// clone.disk into the relocate spec's disk locators, keying off of each
+// override's unit_number to find the matching source VirtualDisk's device
+// key. Overrides replace whatever locator DiskCloneRelocateOperation already
+// produced for that disk, or add a new one if none existed.
+func applyCloneDiskOverrides(d *schema.ResourceData, c *govmomi.Client, l object.VirtualDeviceList, spec *types.VirtualMachineCloneSpec) error {
+	disks := d.Get("clone.0.disk").([]interface{})
+	if len(disks) < 1 {
+		return nil
+	}
+
+	keyByUnit := make(map[int32]int32)
+	for _, device := range l.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		if disk.UnitNumber != nil {
+			keyByUnit[*disk.UnitNumber] = disk.Key
+		}
+	}
+
+	for _, di := range disks {
+		dm := di.(map[string]interface{})
+		unit := int32(dm["unit_number"].(int))
+		key, ok := keyByUnit[unit]
+		if !ok {
+			return fmt.Errorf("clone.disk: no disk with unit_number %d found on source virtual machine or template", unit)
+		}
+
+		backing := &types.VirtualDiskFlatVer2BackingInfo{
+			DiskMode:        dm["disk_mode"].(string),
+			EagerlyScrub:    structure.BoolPtr(dm["eagerly_scrub"].(bool)),
+			ThinProvisioned: structure.BoolPtr(dm["thin_provisioned"].(bool)),
+			Sharing:         dm["sharing"].(string),
+		}
+
+		locator := types.VirtualMachineRelocateSpecDiskLocator{
+			DiskId:          key,
+			DiskBackingInfo: backing,
+		}
+		if dsID, ok := dm["datastore_id"].(string); ok && dsID != "" {
+			ds, err := datastore.FromID(c, dsID)
+			if err != nil {
+				return fmt.Errorf("clone.disk: error locating datastore %q for unit_number %d: %s", dsID, unit, err)
+			}
+			locator.Datastore = ds.Reference()
+		}
+
+		replaced := false
+		for i := range spec.Location.Disk {
+			if spec.Location.Disk[i].DiskId == key {
+				if locator.Datastore.Value == "" {
+					locator.Datastore = spec.Location.Disk[i].Datastore
+				}
+				spec.Location.Disk[i] = locator
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			if locator.Datastore.Value == "" {
+				// No per-disk override datastore and no pre-existing locator
+				// to inherit one from (e.g. a clone.disk entry with no
+				// matching top-level disk block). Fall back to the clone's
+				// overall datastore, which by this point reflects either
+				// datastore_id or an applied Storage DRS recommendation (none
+				// is applied when sdrs_automation_level is manual), rather
+				// than sending vCenter an empty, invalid moref.
+				if spec.Location.Datastore == nil {
+					return fmt.Errorf("clone.disk: no datastore_id set for unit_number %d, and no overall datastore to fall back to (datastore_id is not set and no Storage DRS recommendation was applied)", unit)
+				}
+				locator.Datastore = *spec.Location.Datastore
+			}
+			spec.Location.Disk = append(spec.Location.Disk, locator)
+		}
+	}
+	return nil
+}
+
+// applyStorageDrsPlacement builds a StoragePlacementSpec of type "clone" for
+// the supplied datastore cluster, asks the SDRS StorageResourceManager for a
+// ranked set of datastore recommendations, and applies the top-ranked
+// recommendation into spec.Location.Datastore and each disk locator. This
+// runs before clone.disk overrides are merged in (see the call order in
+// ExpandVirtualMachineCloneSpec above), so spec.Location.Disk only reflects
+// DiskCloneRelocateOperation's output here, not clone.disk yet - a disk is
+// skipped from SDRS ranking because it already has a locator from that
+// relocate operation, not because of any user override. applyCloneDiskOverrides
+// merges clone.disk in afterward and still wins over whatever this function
+// applies.
+func applyStorageDrsPlacement(d *schema.ResourceData, c *govmomi.Client, vm *object.VirtualMachine, l object.VirtualDeviceList, spec *types.VirtualMachineCloneSpec, podID string) ([]interface{}, error) {
+	pod, err := datastore.PodFromID(c, podID)
+	if err != nil {
+		return nil, fmt.Errorf("error locating datastore cluster for VM: %s", err)
+	}
+	podRef := pod.Reference()
+
+	overridden := make(map[int32]struct{})
+	for _, locator := range spec.Location.Disk {
+		overridden[locator.DiskId] = struct{}{}
+	}
+
+	var diskLocators []types.VirtualMachineRelocateSpecDiskLocator
+	for _, device := range l.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		key := disk.Key
+		if _, ok := overridden[key]; ok {
+			// Already has a locator from DiskCloneRelocateOperation; clone.disk
+			// hasn't been merged in yet at this point, so this is never a user
+			// override. Leave it out of SDRS ranking either way.
+			continue
+		}
+		diskLocators = append(diskLocators, types.VirtualMachineRelocateSpecDiskLocator{
+			DiskId: key,
+		})
+	}
+
+	spsSpec := types.StoragePlacementSpec{
+		Type: string(types.StoragePlacementSpecPlacementSpecTypeClone),
+		Vm:   types.NewReference(vm.Reference()),
+		CloneSpec: &types.VirtualMachineCloneSpec{
+			Location: spec.Location,
+			PowerOn:  spec.PowerOn,
+			Template: spec.Template,
+			Config:   spec.Config,
+			Snapshot: spec.Snapshot,
+		},
+		CloneName: d.Get("name").(string),
+		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
+			StoragePod: &podRef,
+			InitialVmConfig: []types.VmPodConfigForPlacement{
+				{
+					Vm:   vm.Reference(),
+					Pod:  podRef,
+					Disk: diskLocators,
+				},
+			},
+		},
+		ResourcePool: spec.Location.Pool,
+	}
+
+	srm := object.NewStorageResourceManager(c.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	result, err := srm.RecommendDatastores(ctx, spsSpec)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting Storage DRS recommendations for datastore cluster %q: %s", podID, err)
+	}
+	if len(result.Recommendations) < 1 {
+		return nil, fmt.Errorf("Storage DRS returned no datastore recommendations for datastore cluster %q", podID)
+	}
+
+	recommendations := make([]interface{}, 0, len(result.Recommendations))
+	for _, rec := range result.Recommendations {
+		var dsID string
+		for _, action := range rec.Action {
+			if saa, ok := action.(*types.StoragePlacementAction); ok {
+				dsID = saa.Destination.Value
+				break
+			}
+		}
+		recommendations = append(recommendations, map[string]interface{}{
+			"key":          rec.Key,
+			"datastore_id": dsID,
+			"rating":       rec.Rating,
+			"reason":       rec.Reason,
+		})
+	}
+
+	if d.Get("clone.0.sdrs_automation_level").(string) == "manual" {
+		log.Printf("[DEBUG] applyStorageDrsPlacement: sdrs_automation_level is manual, surfacing recommendations without applying them")
+		return recommendations, nil
+	}
+
+	top := result.Recommendations[0]
+	for _, action := range top.Action {
+		saa, ok := action.(*types.StoragePlacementAction)
+		if !ok {
+			continue
+		}
+		spec.Location.Datastore = &saa.Destination
+		for _, recDisk := range saa.RelocateSpec.Disk {
+			for i := range spec.Location.Disk {
+				if spec.Location.Disk[i].DiskId == recDisk.DiskId {
+					spec.Location.Disk[i].Datastore = recDisk.Datastore
+				}
+			}
+		}
+	}
+	if spec.Location.Datastore == nil {
+		return nil, fmt.Errorf("Storage DRS recommendation %q for datastore cluster %q did not include a destination datastore", top.Key, podID)
+	}
+	log.Printf("[DEBUG] applyStorageDrsPlacement: Applied Storage DRS recommendation %q for datastore cluster %q", top.Key, podID)
+	return recommendations, nil
 }
 
 // ExpandVirtualMachineInstantCloneSpec creates an instant clone spec for an existing virtual machine.
@@ -377,3 +937,65 @@ func ExpandVirtualMachineInstantCloneSpec(d *schema.ResourceData, c *govmomi.Cli
 	log.Printf("[DEBUG] ExpandVirtualMachineInstantCloneSpec: Instant Clone spec prep complete")
 	return spec, srcVM, nil
 }
+
+// ApplyVirtualMachineInstantCloneCustomization applies the customize block of
+// an instant clone sub-resource to the virtual machine produced by
+// InstantClone_Task. Instant clones do not accept a CustomizationSpec inside
+// VirtualMachineInstantCloneSpec itself, so this is run as a discrete
+// post-task step once the new VM's copy of VMware Tools reports itself as
+// running, using the sub-resource's existing timeout value as the deadline.
+//
+// The resource's Create function must call this immediately after the
+// InstantClone_Task it submits from ExpandVirtualMachineInstantCloneSpec's
+// result completes, using the new VM (not the source VM returned by
+// ExpandVirtualMachineInstantCloneSpec) and the guest OS family resolved the
+// same way ValidateVirtualMachineClone resolves it for the clone sub-resource.
+// Without that call, instantclone.customize is silently never applied.
+func ApplyVirtualMachineInstantCloneCustomization(d *schema.ResourceData, c *govmomi.Client, vm *object.VirtualMachine, family string) error {
+	if len(d.Get("instantclone.0.customize").([]interface{})) < 1 {
+		log.Printf("[DEBUG] ApplyVirtualMachineInstantCloneCustomization: No customization block found, skipping")
+		return nil
+	}
+
+	timeout := time.Duration(d.Get("instantclone.0.timeout").(int)) * time.Minute
+	log.Printf("[DEBUG] ApplyVirtualMachineInstantCloneCustomization: Waiting up to %s for VMware Tools on %s", timeout, vm.Reference().Value)
+	if err := waitForGuestToolsRunning(c, vm, timeout); err != nil {
+		return fmt.Errorf("error waiting for VMware Tools before customization: %s", err)
+	}
+
+	spec := ExpandCustomizationSpec(d, family)
+	cm := guest.NewCustomizationManager(c.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	log.Printf("[DEBUG] ApplyVirtualMachineInstantCloneCustomization: Submitting customization to %s", vm.Reference().Value)
+	task, err := cm.Customize(ctx, vm.Reference(), spec)
+	if err != nil {
+		return fmt.Errorf("error starting guest customization: %s", err)
+	}
+	if err := task.Wait(ctx); err != nil {
+		return fmt.Errorf("error waiting for guest customization to complete: %s", err)
+	}
+	log.Printf("[DEBUG] ApplyVirtualMachineInstantCloneCustomization: Customization of %s complete", vm.Reference().Value)
+	return nil
+}
+
+// waitForGuestToolsRunning polls a virtual machine's guest info until VMware
+// Tools reports a running status, or the supplied timeout elapses.
+func waitForGuestToolsRunning(c *govmomi.Client, vm *object.VirtualMachine, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for {
+		props, err := virtualmachine.Properties(vm)
+		if err != nil {
+			return err
+		}
+		if props.Guest != nil && props.Guest.ToolsRunningStatus == string(types.VirtualMachineToolsRunningStatusGuestToolsRunning) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for VMware Tools to start on %s", vm.Reference().Value)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
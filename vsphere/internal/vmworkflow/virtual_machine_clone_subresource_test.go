@@ -0,0 +1,65 @@
+package vmworkflow
+
+import "testing"
+
+func TestValidateCloneDiskUnitNumbers(t *testing.T) {
+	units := map[int32]struct{}{
+		0: {},
+		1: {},
+	}
+
+	cases := []struct {
+		name    string
+		disks   []interface{}
+		wantErr bool
+	}{
+		{
+			name:  "no overrides",
+			disks: nil,
+		},
+		{
+			name: "override matches an existing disk",
+			disks: []interface{}{
+				map[string]interface{}{
+					"unit_number":      0,
+					"eagerly_scrub":    false,
+					"thin_provisioned": true,
+				},
+			},
+		},
+		{
+			name: "override references a unit_number that does not exist",
+			disks: []interface{}{
+				map[string]interface{}{
+					"unit_number":      5,
+					"eagerly_scrub":    false,
+					"thin_provisioned": false,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "override sets both eagerly_scrub and thin_provisioned",
+			disks: []interface{}{
+				map[string]interface{}{
+					"unit_number":      1,
+					"eagerly_scrub":    true,
+					"thin_provisioned": true,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCloneDiskUnitNumbers(tc.disks, units)
+			if tc.wantErr && err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("expected no error, got: %s", err)
+			}
+		})
+	}
+}
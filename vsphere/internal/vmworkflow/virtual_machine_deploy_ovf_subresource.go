@@ -0,0 +1,398 @@
+package vmworkflow
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/datastore"
+	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/network"
+	"github.com/terraform-providers/terraform-provider-vsphere/vsphere/internal/helper/provider"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ovfDiskProvisioningAllowedValues are the allowed values for the
+// disk_provisioning field on the ovf_deploy sub-resource.
+var ovfDiskProvisioningAllowedValues = []string{
+	"thin",
+	"thick",
+	"eagerZeroedThick",
+}
+
+// VirtualMachineDeployOvfSchema represents the schema for the OVF/OVA deploy
+// sub-resource.
+//
+// This is a workflow for vsphere_virtual_machine that facilitates the
+// creation of a virtual machine by deploying an OVF or OVA template, as an
+// alternative to cloning from an existing template UUID.
+func VirtualMachineDeployOvfSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"remote_url": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Description:   "URL to the OVF template that should be deployed. The response body is read as the OVF descriptor itself; OVA archives are only unpacked when given through local_ovf_path.",
+			ConflictsWith: []string{"ovf_deploy.0.local_ovf_path"},
+		},
+		"local_ovf_path": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			Description:   "Path on the machine running Terraform to the OVF/OVA template that should be deployed.",
+			ConflictsWith: []string{"ovf_deploy.0.remote_url"},
+		},
+		"deployment_option": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The key of the deployment option to use, from the template's list of deployment options.",
+		},
+		"disk_provisioning": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "thin",
+			Description:  "An optional disk provisioning override for the disks in the template. Can be one of thin, thick, or eagerZeroedThick.",
+			ValidateFunc: validation.StringInSlice(ovfDiskProvisioningAllowedValues, false),
+		},
+		"ip_protocol": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "IPv4",
+			Description: "The IP protocol to use for this template, one of IPv4 or IPv6.",
+		},
+		"ip_allocation_policy": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "dhcpPolicy",
+			Description: "The IP allocation policy to use, one of dhcpPolicy, transientPolicy, fixedPolicy, or fixedAllocatedPolicy.",
+		},
+		"ovf_network_map": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Description: "Mapping of network names in the OVF/OVA template to the managed object ID of the network in this vSphere environment that they should be deployed to.",
+		},
+	}
+}
+
+// expandOvfVAppProperties translates the vapp.properties map into the
+// OvfCreateImportSpecParams.PropertyMapping that the OVF manager needs to
+// populate Config.VAppConfig.Property on the resulting VM, so that
+// ValidateVAppTransport continues to work for post-deploy ISO/OVF
+// environment transports sourced from an OVF/OVA template.
+func expandOvfVAppProperties(d *schema.ResourceData) []types.KeyValue {
+	props := d.Get("vapp.0.properties").(map[string]interface{})
+	var mapping []types.KeyValue
+	for k, v := range props {
+		mapping = append(mapping, types.KeyValue{Key: k, Value: v.(string)})
+	}
+	return mapping
+}
+
+// ExpandVirtualMachineDeployOvfSpec reads an OVF/OVA template (local or
+// remote), creates an import spec for it with the OVF manager, and starts the
+// import by passing that ImportSpec unchanged to ResourcePool.ImportVApp; the
+// ImportSpec is not converted into a VirtualMachineConfigSpec. It returns hs
+// as passed in, the import lease, and the import spec result, whose FileItem
+// list must be uploaded afterwards to actually transfer the disks.
+func ExpandVirtualMachineDeployOvfSpec(d *schema.ResourceData, c *govmomi.Client, rp *object.ResourcePool, fo *object.Folder, hs *object.HostSystem) (*object.HostSystem, *nfc.Lease, *types.OvfCreateImportSpecResult, error) {
+	log.Printf("[DEBUG] ExpandVirtualMachineDeployOvfSpec: Preparing OVF/OVA import spec")
+
+	descriptor, err := readOvfDescriptor(d)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	dsID := d.Get("datastore_id").(string)
+	ds, err := datastore.FromID(c, dsID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error locating datastore for OVF/OVA deployment: %s", err)
+	}
+
+	networkMap := d.Get("ovf_deploy.0.ovf_network_map").(map[string]interface{})
+	var mappings []types.OvfNetworkMapping
+	for src, dst := range networkMap {
+		net, err := network.FromID(c, dst.(string))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error locating network %q for OVF network mapping %q: %s", dst, src, err)
+		}
+		mappings = append(mappings, types.OvfNetworkMapping{
+			Name:    src,
+			Network: net.Reference(),
+		})
+	}
+
+	cisp := types.OvfCreateImportSpecParams{
+		DiskProvisioning:   d.Get("ovf_deploy.0.disk_provisioning").(string),
+		EntityName:         d.Get("name").(string),
+		IpAllocationPolicy: d.Get("ovf_deploy.0.ip_allocation_policy").(string),
+		IpProtocol:         d.Get("ovf_deploy.0.ip_protocol").(string),
+		NetworkMapping:     mappings,
+		DeploymentOption:   d.Get("ovf_deploy.0.deployment_option").(string),
+		PropertyMapping:    expandOvfVAppProperties(d),
+	}
+
+	m := ovf.NewManager(c.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	spec, err := m.CreateImportSpec(ctx, descriptor, rp, ds, cisp)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error creating OVF/OVA import spec: %s", err)
+	}
+	if spec.Error != nil && len(spec.Error) > 0 {
+		return nil, nil, nil, fmt.Errorf("error in OVF/OVA import spec: %s", spec.Error[0].LocalizedMessage)
+	}
+
+	lease, err := rp.ImportVApp(ctx, spec.ImportSpec, fo, hs)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error starting OVF/OVA import lease: %s", err)
+	}
+	return hs, lease, spec, nil
+}
+
+// UploadVirtualMachineDeployOvfFiles uploads every FileItem in an OVF/OVA
+// import spec to the device URLs handed out by the import lease, reporting
+// progress back to the lease's updater so the API continues to see activity
+// while the potentially long-running transfer is in progress.
+func UploadVirtualMachineDeployOvfFiles(d *schema.ResourceData, lease *nfc.Lease, spec *types.OvfCreateImportSpecResult) error {
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer waitCancel()
+
+	info, err := lease.Wait(waitCtx, spec.FileItem)
+	if err != nil {
+		return fmt.Errorf("error waiting on OVF/OVA import lease: %s", err)
+	}
+
+	// The disk transfer itself can run for a long time on a multi-gigabyte
+	// template - far longer than provider.DefaultAPITimeout is meant to
+	// bound a single API call for. Run it on an undecorated context; the
+	// updater below keeps the lease alive for as long as the upload is
+	// actually making progress.
+	ctx := context.Background()
+	updater := lease.StartUpdater(ctx, info)
+	defer updater.Done()
+
+	ovaPath := ovaSourcePath(d)
+	remoteBaseURL := ovfRemoteBaseURL(d)
+	for _, item := range info.Items {
+		if ovaPath == "" && remoteBaseURL == "" && !filepath.IsAbs(item.Path) {
+			// A bare local OVF's file hrefs are relative to the descriptor.
+			item.Path = filepath.Join(filepath.Dir(d.Get("ovf_deploy.0.local_ovf_path").(string)), item.Path)
+		}
+		if err := uploadOvfFileItem(ctx, lease, item, ovaPath, remoteBaseURL); err != nil {
+			lease.Abort(ctx, &types.LocalizedMethodFault{
+				Fault: &types.SystemError{RuntimeFault: types.RuntimeFault{}, Reason: err.Error()},
+			})
+			return fmt.Errorf("error uploading OVF/OVA disk %q: %s", item.Path, err)
+		}
+	}
+
+	completeCtx, completeCancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer completeCancel()
+	if err := lease.Complete(completeCtx); err != nil {
+		return fmt.Errorf("error completing OVF/OVA import lease: %s", err)
+	}
+	return nil
+}
+
+func uploadOvfFileItem(ctx context.Context, lease *nfc.Lease, item nfc.FileItem, ovaPath, remoteBaseURL string) error {
+	f, size, err := openOvfFileItem(item, ovaPath, remoteBaseURL)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	opts := soap.Upload{
+		ContentLength: size,
+		Method:        http.MethodPut,
+	}
+	return lease.Upload(ctx, item, f, opts)
+}
+
+// openOvfFileItem opens the disk data for a single FileItem from an import
+// spec. ovaPath is non-empty when the template being deployed is an OVA
+// archive, in which case the disk is a member of that archive rather than a
+// sibling file alongside the OVF descriptor on disk. remoteBaseURL is
+// non-empty when the template is being deployed from remote_url, in which
+// case item.Path is a relative href from the OVF descriptor - not a local
+// path - and must be fetched over HTTP instead.
+func openOvfFileItem(item nfc.FileItem, ovaPath, remoteBaseURL string) (io.ReadCloser, int64, error) {
+	if remoteBaseURL != "" {
+		return openRemoteOvfFileItem(remoteBaseURL, item.Path)
+	}
+	if ovaPath != "" {
+		return openOvaFileItem(ovaPath, item.Path)
+	}
+	f, err := os.Open(item.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
+
+// openRemoteOvfFileItem fetches a disk referenced by a remote OVF/OVA
+// template over HTTP, resolving its descriptor-relative href against
+// remoteBaseURL - the same base URL that the descriptor itself was read
+// from in readOvfDescriptor.
+func openRemoteOvfFileItem(remoteBaseURL, name string) (io.ReadCloser, int64, error) {
+	u, err := url.Parse(remoteBaseURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error parsing remote_url %q: %s", remoteBaseURL, err)
+	}
+	ref, err := url.Parse(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error parsing OVF/OVA disk href %q: %s", name, err)
+	}
+	diskURL := u.ResolveReference(ref).String()
+
+	resp, err := http.Get(diskURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching OVF/OVA disk %q: %s", diskURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("error fetching OVF/OVA disk %q: server returned %q", diskURL, resp.Status)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// openOvaFileItem returns a reader positioned at the tar member of an OVA
+// archive matching name, for uploading a disk that is bundled inside the
+// archive instead of present as a standalone file.
+func openOvaFileItem(ovaPath, name string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(ovaPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error opening OVA template %q: %s", ovaPath, err)
+	}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			f.Close()
+			return nil, 0, fmt.Errorf("disk %q not found in OVA template %q", name, ovaPath)
+		}
+		if err != nil {
+			f.Close()
+			return nil, 0, fmt.Errorf("error reading OVA template %q: %s", ovaPath, err)
+		}
+		if filepath.Base(hdr.Name) != filepath.Base(name) {
+			continue
+		}
+		return &ovaMemberReader{Reader: tr, f: f}, hdr.Size, nil
+	}
+}
+
+// ovaMemberReader adapts a *tar.Reader positioned at a single member into an
+// io.ReadCloser, closing the underlying archive file handle once the caller
+// is done reading that member.
+type ovaMemberReader struct {
+	io.Reader
+	f *os.File
+}
+
+func (r *ovaMemberReader) Close() error {
+	return r.f.Close()
+}
+
+// ovaSourcePath returns the local path to deploy's OVA archive, or "" if the
+// template being deployed is a bare OVF - either local sibling files or a
+// remote URL - neither of which need member-based archive handling for
+// their disk uploads.
+func ovaSourcePath(d *schema.ResourceData) string {
+	path := d.Get("ovf_deploy.0.local_ovf_path").(string)
+	if strings.EqualFold(filepath.Ext(path), ".ova") {
+		return path
+	}
+	return ""
+}
+
+// ovfRemoteBaseURL returns the remote_url a template's OVF descriptor was
+// fetched from, or "" if the template is being deployed from local_ovf_path
+// instead. Disk FileItem hrefs are resolved against this same URL, the same
+// way readOvfDescriptor resolves the descriptor itself.
+func ovfRemoteBaseURL(d *schema.ResourceData) string {
+	v, ok := d.GetOk("ovf_deploy.0.remote_url")
+	if !ok {
+		return ""
+	}
+	return v.(string)
+}
+
+// readOvfDescriptor returns the raw OVF descriptor XML for either a remote
+// URL or a local OVF/OVA path, matching whichever of remote_url or
+// local_ovf_path was set on the sub-resource. A local_ovf_path ending in
+// .ova is treated as a tar archive and its .ovf member is extracted; any
+// other local_ovf_path is read directly as the descriptor itself. A
+// remote_url response body is always returned as the descriptor as is, so a
+// remote OVA archive is not unpacked.
+func readOvfDescriptor(d *schema.ResourceData) (string, error) {
+	if v, ok := d.GetOk("ovf_deploy.0.remote_url"); ok {
+		resp, err := http.Get(v.(string))
+		if err != nil {
+			return "", fmt.Errorf("error fetching OVF/OVA template %q: %s", v, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("error reading OVF/OVA template %q: %s", v, err)
+		}
+		return string(data), nil
+	}
+	if v, ok := d.GetOk("ovf_deploy.0.local_ovf_path"); ok {
+		path := v.(string)
+		if strings.EqualFold(filepath.Ext(path), ".ova") {
+			return readOvfDescriptorFromOva(path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading OVF/OVA template %q: %s", path, err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("one of ovf_deploy.remote_url or ovf_deploy.local_ovf_path must be set")
+}
+
+// readOvfDescriptorFromOva returns the OVF descriptor XML stored as the
+// .ovf member of an OVA tar archive.
+func readOvfDescriptorFromOva(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening OVA template %q: %s", path, err)
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading OVA template %q: %s", path, err)
+		}
+		if !strings.EqualFold(filepath.Ext(hdr.Name), ".ovf") {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return "", fmt.Errorf("error reading OVF descriptor from OVA template %q: %s", path, err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("no .ovf descriptor found in OVA template %q", path)
+}